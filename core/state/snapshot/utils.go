@@ -24,8 +24,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 // CheckDanglingStorage iterates the snap storage data, and verifies that all
@@ -91,6 +93,67 @@ func checkDanglingMemStorage(db ethdb.KeyValueStore) error {
 	return nil
 }
 
+// StorageFingerprint computes a digest over all the storage slots of the given
+// account in the disk snapshot layer. Slots are hashed in key order as pairs of
+// slot hash and decoded slot value, so two nodes can cheaply compare their
+// persisted storage snapshots for divergence.
+func StorageFingerprint(db ethdb.KeyValueStore, accountHash common.Hash) (common.Hash, error) {
+	var (
+		hasher = crypto.NewKeccakState()
+		offset = len(rawdb.SnapshotStoragePrefix) + common.HashLength
+		it     = rawdb.IterateStorageSnapshots(db, accountHash)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		content, _, err := rlp.SplitString(it.Value())
+		if err == nil && len(content) > common.HashLength {
+			err = fmt.Errorf("oversized value, %d bytes", len(content))
+		}
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("invalid storage slot %x: %v", it.Key()[offset:], err)
+		}
+		value := common.BytesToHash(content)
+		hasher.Write(it.Key()[offset:])
+		hasher.Write(value.Bytes())
+	}
+	if err := it.Error(); err != nil {
+		return common.Hash{}, err
+	}
+	var digest common.Hash
+	hasher.Read(digest[:])
+	return digest, nil
+}
+
+// ValidationReport summarizes the storage snapshot of a single account.
+type ValidationReport struct {
+	Slots   int           // Number of slots with a well-formed value
+	Corrupt []common.Hash // Hashes of the slots whose value failed to decode
+}
+
+// ValidateAccountStorage iterates all the storage slots of the given account in
+// the disk snapshot layer, decodes each value and reports the number of valid
+// slots along with the hashes of the corrupted ones. An error is only returned
+// if the iteration itself fails.
+func ValidateAccountStorage(db ethdb.KeyValueStore, accountHash common.Hash) (ValidationReport, error) {
+	var (
+		report ValidationReport
+		offset = len(rawdb.SnapshotStoragePrefix) + common.HashLength
+		it     = rawdb.IterateStorageSnapshots(db, accountHash)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		content, _, err := rlp.SplitString(it.Value())
+		if err != nil || len(content) == 0 || len(content) > common.HashLength {
+			report.Corrupt = append(report.Corrupt, common.BytesToHash(it.Key()[offset:]))
+			continue
+		}
+		report.Slots++
+	}
+	return report, it.Error()
+}
+
 // CheckJournalAccount shows information about an account, from the disk layer and
 // up through the diff layers.
 func CheckJournalAccount(db ethdb.KeyValueStore, hash common.Hash) error {
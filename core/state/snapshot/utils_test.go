@@ -0,0 +1,100 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/exp/slices"
+)
+
+// Tests that the storage fingerprint is stable across reads, isolated from
+// other accounts and sensitive to any slot change.
+func TestStorageFingerprint(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		account = common.Hash{0x1}
+	)
+	for i := byte(1); i <= 10; i++ {
+		val, _ := rlp.EncodeToBytes([]byte{i})
+		rawdb.WriteStorageSnapshot(db, account, common.Hash{i}, val)
+	}
+	base, err := StorageFingerprint(db, account)
+	if err != nil {
+		t.Fatalf("failed to compute fingerprint: %v", err)
+	}
+	if again, _ := StorageFingerprint(db, account); again != base {
+		t.Fatalf("fingerprint unstable: have %x, want %x", again, base)
+	}
+	// Slots of neighbouring accounts must not affect the fingerprint
+	val, _ := rlp.EncodeToBytes([]byte{0xff})
+	rawdb.WriteStorageSnapshot(db, common.Hash{0x2}, common.Hash{0x1}, val)
+	if have, _ := StorageFingerprint(db, account); have != base {
+		t.Fatalf("fingerprint affected by other account: have %x, want %x", have, base)
+	}
+	// Modifying a slot must change the fingerprint
+	rawdb.WriteStorageSnapshot(db, account, common.Hash{0x5}, val)
+	if have, _ := StorageFingerprint(db, account); have == base {
+		t.Fatal("fingerprint unchanged after slot update")
+	}
+	// Corrupted slots must be reported instead of hashed
+	oversized, _ := rlp.EncodeToBytes(make([]byte, common.HashLength+1))
+	for _, blob := range [][]byte{
+		{0x82, 0x01}, // truncated
+		{0xc1, 0x01}, // list
+		oversized,    // longer than a hash
+	} {
+		rawdb.WriteStorageSnapshot(db, account, common.Hash{0x5}, blob)
+		if _, err := StorageFingerprint(db, account); err == nil {
+			t.Fatalf("corrupted slot %x not reported", blob)
+		}
+	}
+}
+
+// Tests that storage validation counts the well-formed slots and flags the
+// corrupted ones.
+func TestValidateAccountStorage(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		account = common.Hash{0x1}
+	)
+	for i := byte(1); i <= 5; i++ {
+		val, _ := rlp.EncodeToBytes([]byte{i})
+		rawdb.WriteStorageSnapshot(db, account, common.Hash{i}, val)
+	}
+	rawdb.WriteStorageSnapshot(db, account, common.Hash{0x10}, []byte{0x82, 0x01}) // truncated
+	rawdb.WriteStorageSnapshot(db, account, common.Hash{0x11}, []byte{0xc1, 0x01}) // list
+	rawdb.WriteStorageSnapshot(db, account, common.Hash{0x12}, []byte{0x80})       // empty
+
+	// Slots of neighbouring accounts must not be included
+	rawdb.WriteStorageSnapshot(db, common.Hash{0x2}, common.Hash{0x1}, []byte{0x82, 0x01})
+
+	report, err := ValidateAccountStorage(db, account)
+	if err != nil {
+		t.Fatalf("failed to validate storage: %v", err)
+	}
+	if report.Slots != 5 {
+		t.Fatalf("valid slot count mismatch: have %d, want 5", report.Slots)
+	}
+	want := []common.Hash{{0x10}, {0x11}, {0x12}}
+	if !slices.Equal(report.Corrupt, want) {
+		t.Fatalf("corrupt slot mismatch: have %x, want %x", report.Corrupt, want)
+	}
+}
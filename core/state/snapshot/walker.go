@@ -0,0 +1,106 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// AccountStorageWalker iterates over the accounts in the persistent snapshot
+// which own a non-empty storage trie, yielding the account hash along with its
+// storage root. Accounts without storage (EOAs and storage-less contracts) are
+// skipped.
+type AccountStorageWalker struct {
+	it   ethdb.Iterator
+	hash common.Hash // Hash of the account the walker is currently at
+	root common.Hash // Storage root of the account the walker is currently at
+	err  error       // Failure that terminated the iteration, if any
+}
+
+// NewAccountStorageWalker creates a walker over the account snapshot, starting
+// at the given account hash (inclusive). Use ResumeAccountStorageWalker to
+// continue an interrupted walk without revisiting the last yielded account.
+func NewAccountStorageWalker(db ethdb.KeyValueStore, startAccount common.Hash) *AccountStorageWalker {
+	pos := common.TrimRightZeroes(startAccount[:])
+	return &AccountStorageWalker{
+		it: rawdb.NewKeyLengthIterator(db.NewIterator(rawdb.SnapshotAccountPrefix, pos), len(rawdb.SnapshotAccountPrefix)+common.HashLength),
+	}
+}
+
+// ResumeAccountStorageWalker creates a walker over the account snapshot which
+// continues an interrupted walk, starting right after the given last yielded
+// account hash (exclusive).
+func ResumeAccountStorageWalker(db ethdb.KeyValueStore, lastAccount common.Hash) *AccountStorageWalker {
+	next := increaseKey(common.CopyBytes(lastAccount[:]))
+	if next == nil {
+		return new(AccountStorageWalker) // The last account was the final one
+	}
+	return NewAccountStorageWalker(db, common.BytesToHash(next))
+}
+
+// Next steps the walker forward to the next account with storage, returning
+// false if the walker is exhausted or an error occurred.
+func (w *AccountStorageWalker) Next() bool {
+	if w.it == nil {
+		return false
+	}
+	for w.it.Next() {
+		account, err := types.FullAccount(w.it.Value())
+		if err != nil {
+			w.err = fmt.Errorf("invalid account %x: %v", w.it.Key()[len(rawdb.SnapshotAccountPrefix):], err)
+			w.Release()
+			return false
+		}
+		if account.Root == types.EmptyRootHash {
+			continue
+		}
+		w.hash = common.BytesToHash(w.it.Key()[len(rawdb.SnapshotAccountPrefix):])
+		w.root = account.Root
+		return true
+	}
+	w.err = w.it.Error()
+	w.Release()
+	return false
+}
+
+// Hash returns the hash of the account the walker is currently at.
+func (w *AccountStorageWalker) Hash() common.Hash {
+	return w.hash
+}
+
+// Root returns the storage root of the account the walker is currently at.
+func (w *AccountStorageWalker) Root() common.Hash {
+	return w.root
+}
+
+// Error returns any failure that occurred during the walk.
+func (w *AccountStorageWalker) Error() error {
+	return w.err
+}
+
+// Release releases the database resources held by the walker.
+func (w *AccountStorageWalker) Release() {
+	if w.it != nil {
+		w.it.Release()
+		w.it = nil
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/exp/slices"
+)
+
+// Tests that the account storage walker yields only the accounts with storage,
+// in order, and that it can be resumed from an arbitrary position.
+func TestAccountStorageWalker(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+
+	var want []common.Hash
+	for i := byte(1); i <= 10; i++ {
+		account := types.StateAccount{
+			Balance:  big.NewInt(int64(i)),
+			Root:     types.EmptyRootHash,
+			CodeHash: types.EmptyCodeHash.Bytes(),
+		}
+		hash := common.Hash{i}
+		if i%3 == 0 {
+			account.Root = common.Hash{0xff, i}
+			want = append(want, hash)
+		}
+		rawdb.WriteAccountSnapshot(db, hash, types.SlimAccountRLP(account))
+	}
+	// Storage entries must not confuse the walker
+	rawdb.WriteStorageSnapshot(db, common.Hash{3}, common.Hash{1}, []byte{0x1})
+
+	collect := func(w *AccountStorageWalker) []common.Hash {
+		var hashes []common.Hash
+
+		defer w.Release()
+		for w.Next() {
+			if have, want := w.Root(), (common.Hash{0xff, w.Hash()[0]}); have != want {
+				t.Fatalf("account %x: storage root mismatch: have %x, want %x", w.Hash(), have, want)
+			}
+			hashes = append(hashes, w.Hash())
+		}
+		if err := w.Error(); err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		return hashes
+	}
+	walk := func(start common.Hash) []common.Hash {
+		return collect(NewAccountStorageWalker(db, start))
+	}
+	resume := func(last common.Hash) []common.Hash {
+		return collect(ResumeAccountStorageWalker(db, last))
+	}
+	if have := walk(common.Hash{}); !slices.Equal(have, want) {
+		t.Fatalf("account mismatch: have %x, want %x", have, want)
+	}
+	if have := walk(common.Hash{6}); !slices.Equal(have, want[1:]) {
+		t.Fatalf("positioned account mismatch: have %x, want %x", have, want[1:])
+	}
+	if have := walk(common.Hash{7}); !slices.Equal(have, want[2:]) {
+		t.Fatalf("positioned account mismatch: have %x, want %x", have, want[2:])
+	}
+	// Resuming from a yielded account must not revisit it
+	for i, last := range want {
+		if have := resume(last); !slices.Equal(have, want[i+1:]) {
+			t.Fatalf("resumed account mismatch after %x: have %x, want %x", last, have, want[i+1:])
+		}
+	}
+	// Resuming after the largest possible hash must yield nothing
+	last := common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	if have := resume(last); len(have) != 0 {
+		t.Fatalf("resumed past the end: have %x", have)
+	}
+}
+
+// Tests that the account storage walker reports undecodable accounts.
+func TestAccountStorageWalkerCorrupt(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteAccountSnapshot(db, common.Hash{1}, []byte{0xde, 0xad})
+
+	w := NewAccountStorageWalker(db, common.Hash{})
+	defer w.Release()
+	if w.Next() {
+		t.Fatal("walker yielded corrupt account")
+	}
+	if w.Error() == nil {
+		t.Fatal("walker did not report corrupt account")
+	}
+}
@@ -29,6 +29,8 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie/trienode"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 type Code []byte
@@ -39,9 +41,12 @@ func (c Code) String() string {
 
 type Storage map[common.Hash]common.Hash
 
+// String returns the storage entries rendered one per line, sorted by key.
 func (s Storage) String() (str string) {
-	for key, value := range s {
-		str += fmt.Sprintf("%X : %X\n", key, value)
+	keys := maps.Keys(s)
+	slices.SortFunc(keys, common.Hash.Cmp)
+	for _, key := range keys {
+		str += fmt.Sprintf("%X : %X\n", key, s[key])
 	}
 	return
 }
@@ -206,6 +211,9 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 	}
 	// If the snapshot is unavailable or reading from it fails, load from the database.
 	if s.db.snap == nil || err != nil {
+		if s.db.OnTrieFallback != nil {
+			s.db.OnTrieFallback(s.address, key)
+		}
 		start := time.Now()
 		tr, err := s.getTrie()
 		if err != nil {
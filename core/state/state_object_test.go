@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -44,3 +45,21 @@ func BenchmarkCutCustomTrim(b *testing.B) {
 		common.TrimLeftZeroes(value[:])
 	}
 }
+
+func TestStorageStringSorted(t *testing.T) {
+	storage := make(Storage)
+	for i := 16; i > 0; i-- {
+		storage[common.Hash{byte(i)}] = common.Hash{byte(i * 2)}
+	}
+	str := storage.String()
+	if again := storage.String(); again != str {
+		t.Fatalf("non-deterministic output:\n%s\nvs\n%s", str, again)
+	}
+	var want string
+	for i := 1; i <= 16; i++ {
+		want += fmt.Sprintf("%X : %X\n", common.Hash{byte(i)}, common.Hash{byte(i * 2)})
+	}
+	if str != want {
+		t.Fatalf("output mismatch:\nhave:\n%s\nwant:\n%s", str, want)
+	}
+}
@@ -118,6 +118,10 @@ type StateDB struct {
 	// Transient storage
 	transientStorage transientStorage
 
+	// OnTrieFallback, if set, is invoked whenever a committed storage slot
+	// is loaded from the storage trie instead of the snapshot.
+	OnTrieFallback func(addr common.Address, key common.Hash)
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -377,6 +381,21 @@ func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) commo
 	return common.Hash{}
 }
 
+// SnapStorageRaw retrieves a copy of the raw snapshot blob of the given storage
+// slot, as stored in the prefix-zero trimmed rlp format. The slot is identified
+// by the account hash and the slot key hash. A missing slot is reported as
+// nil, nil. An error is returned if the snapshot is not available.
+func (s *StateDB) SnapStorageRaw(addrHash, keyHash common.Hash) ([]byte, error) {
+	if s.snap == nil {
+		return nil, errors.New("snapshot is not available")
+	}
+	blob, err := s.snap.Storage(addrHash, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	return common.CopyBytes(blob), nil
+}
+
 // Database retrieves the low level database supporting the lower level trie ops.
 func (s *StateDB) Database() Database {
 	return s.db
@@ -782,6 +801,8 @@ func (s *StateDB) Copy() *StateDB {
 		journal:              newJournal(),
 		hasher:               crypto.NewKeccakState(),
 
+		OnTrieFallback: s.OnTrieFallback,
+
 		// In order for the block producer to be able to use and make additions
 		// to the snapshot tree, we need to copy that as well. Otherwise, any
 		// block mined by ourselves will cause gaps in the tree, and force the
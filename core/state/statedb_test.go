@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
@@ -1097,3 +1098,99 @@ func TestResetObject(t *testing.T) {
 		t.Fatalf("Unexpected storage slot value %v", slot)
 	}
 }
+
+func TestTrieFallbackCallback(t *testing.T) {
+	var (
+		memdb    = rawdb.NewMemoryDatabase()
+		db       = NewDatabase(memdb)
+		state, _ = New(types.EmptyRootHash, db, nil)
+		addr     = common.HexToAddress("0x1")
+		slot     = common.HexToHash("0x1")
+		value    = common.HexToHash("0x2")
+	)
+	state.SetState(addr, slot, value)
+	root, err := state.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Reopen the state without a snapshot, forcing storage reads to the trie
+	state, err = New(root, db, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen state: %v", err)
+	}
+	var (
+		calls   int
+		gotAddr common.Address
+		gotKey  common.Hash
+	)
+	state.OnTrieFallback = func(addr common.Address, key common.Hash) {
+		calls++
+		gotAddr, gotKey = addr, key
+	}
+	if have := state.GetCommittedState(addr, slot); have != value {
+		t.Fatalf("state mismatch: have %x, want %x", have, value)
+	}
+	if calls != 1 {
+		t.Fatalf("callback invocation mismatch: have %d, want 1", calls)
+	}
+	if gotAddr != addr || gotKey != slot {
+		t.Fatalf("callback argument mismatch: have (%x, %x), want (%x, %x)", gotAddr, gotKey, addr, slot)
+	}
+	// Cached reads must not trigger the callback again
+	state.GetCommittedState(addr, slot)
+	if calls != 1 {
+		t.Fatalf("callback invoked for cached read: have %d calls, want 1", calls)
+	}
+}
+
+func TestSnapStorageRaw(t *testing.T) {
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		tdb      = trie.NewDatabase(disk, nil)
+		db       = NewDatabaseWithNodeDB(disk, tdb)
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, types.EmptyRootHash)
+		state, _ = New(types.EmptyRootHash, db, snaps)
+		addr     = common.HexToAddress("0x1")
+		slot     = common.HexToHash("0x1")
+		value    = common.HexToHash("0xdeadbeef")
+	)
+	// Missing slots must be reported as empty blobs
+	if enc, err := state.SnapStorageRaw(crypto.Keccak256Hash(addr.Bytes()), crypto.Keccak256Hash(slot.Bytes())); err != nil || len(enc) != 0 {
+		t.Fatalf("unexpected result on empty snapshot: %x, %v", enc, err)
+	}
+	state.SetBalance(addr, big.NewInt(1))
+	state.SetState(addr, slot, value)
+	root, _ := state.Commit(0, true)
+
+	state, _ = New(root, db, snaps)
+	enc, err := state.SnapStorageRaw(crypto.Keccak256Hash(addr.Bytes()), crypto.Keccak256Hash(slot.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read raw snapshot storage: %v", err)
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		t.Fatalf("failed to decode raw snapshot storage: %v", err)
+	}
+	if have, want := common.BytesToHash(content), state.GetCommittedState(addr, slot); have != want {
+		t.Fatalf("value mismatch: have %x, want %x", have, want)
+	}
+	if have := common.BytesToHash(content); have != value {
+		t.Fatalf("value mismatch: have %x, want %x", have, value)
+	}
+	// Mutating the returned blob must not corrupt the snapshot
+	for i := range enc {
+		enc[i] = 0xff
+	}
+	again, err := state.SnapStorageRaw(crypto.Keccak256Hash(addr.Bytes()), crypto.Keccak256Hash(slot.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read raw snapshot storage: %v", err)
+	}
+	if _, content, _, _ := rlp.Split(again); common.BytesToHash(content) != value {
+		t.Fatalf("snapshot corrupted via returned blob: have %x", again)
+	}
+	// Raw reads must fail without a snapshot
+	state, _ = New(root, db, nil)
+	if _, err := state.SnapStorageRaw(crypto.Keccak256Hash(addr.Bytes()), crypto.Keccak256Hash(slot.Bytes())); err == nil {
+		t.Fatal("expected error without snapshot")
+	}
+}
@@ -204,6 +204,38 @@ func (db *Database) Len() int {
 	return len(db.db)
 }
 
+// Snapshot returns a deep copy of the entire contents of the memory database,
+// which can later be handed to Restore to roll the database back.
+func (db *Database) Snapshot() (map[string][]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, errMemorydbClosed
+	}
+	contents := make(map[string][]byte, len(db.db))
+	for key, value := range db.db {
+		contents[key] = common.CopyBytes(value)
+	}
+	return contents, nil
+}
+
+// Restore atomically replaces the entire contents of the memory database with
+// a deep copy of the given key-value set, typically obtained via Snapshot.
+func (db *Database) Restore(contents map[string][]byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return errMemorydbClosed
+	}
+	db.db = make(map[string][]byte, len(contents))
+	for key, value := range contents {
+		db.db[key] = common.CopyBytes(value)
+	}
+	return nil
+}
+
 // keyvalue is a key-value tuple tagged with a deletion field to allow creating
 // memory-database write batches.
 type keyvalue struct {
@@ -30,3 +30,65 @@ func TestMemoryDB(t *testing.T) {
 		})
 	})
 }
+
+func TestMemoryDBSnapshotRestore(t *testing.T) {
+	db := New()
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("b"), []byte("2"))
+
+	contents, err := db.Snapshot()
+	if err != nil {
+		t.Fatalf("failed to snapshot database: %v", err)
+	}
+	// Mutating the snapshotted contents must not leak into the database
+	contents["a"][0] = 'x'
+	contents["c"] = []byte("5")
+	if have, _ := db.Get([]byte("a")); string(have) != "1" {
+		t.Fatalf("snapshot aliased: have %q, want %q", have, "1")
+	}
+	if ok, _ := db.Has([]byte("c")); ok {
+		t.Fatal("entry added to snapshot leaked into database")
+	}
+	contents["a"][0] = '1'
+	delete(contents, "c")
+
+	// Mutate the database in every possible way after taking the snapshot
+	db.Put([]byte("a"), []byte("3"))
+	db.Delete([]byte("b"))
+	db.Put([]byte("c"), []byte("4"))
+
+	if err := db.Restore(contents); err != nil {
+		t.Fatalf("failed to restore database: %v", err)
+	}
+	if db.Len() != 2 {
+		t.Fatalf("entry count mismatch: have %d, want 2", db.Len())
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		have, err := db.Get([]byte(key))
+		if err != nil {
+			t.Fatalf("failed to retrieve %q: %v", key, err)
+		}
+		if string(have) != want {
+			t.Fatalf("value mismatch for %q: have %q, want %q", key, have, want)
+		}
+	}
+	if ok, _ := db.Has([]byte("c")); ok {
+		t.Fatal("entry added after snapshot survived restore")
+	}
+	// Mutating the restored-from contents must not leak into the database
+	contents["a"][0] = 'x'
+	if have, _ := db.Get([]byte("a")); string(have) != "1" {
+		t.Fatalf("restored value aliased: have %q, want %q", have, "1")
+	}
+	// A closed database must be neither snapshotted nor revived
+	db.Close()
+	if _, err := db.Snapshot(); err != errMemorydbClosed {
+		t.Fatalf("snapshot error mismatch: have %v, want %v", err, errMemorydbClosed)
+	}
+	if err := db.Restore(contents); err != errMemorydbClosed {
+		t.Fatalf("restore error mismatch: have %v, want %v", err, errMemorydbClosed)
+	}
+	if _, err := db.Get([]byte("a")); err != errMemorydbClosed {
+		t.Fatalf("closed database revived by restore: %v", err)
+	}
+}